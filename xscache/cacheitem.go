@@ -23,28 +23,83 @@ type CacheItem struct {
 	// 该元素访问次数
 	accessCount int64
 
-	// 缓存在被移除前触发的回到函数
-	aboutToExpire func(key interface{})
+	// 缓存在被移除前触发的回调函数列表, 通过AddAboutToExpireCallback注册
+	aboutToExpireCallbacks []expireCallbackEntry
+	// 下一个待分配的回调ID
+	nextCallbackID CallbackID
+
+	// 过期模式, 决定lifeSpan/expireAt如何被expirationCheck解释
+	expireMode ExpireMode
+	// ExpireAbsolute/ExpireAt模式下元素的绝对过期时间点, 对ExpireSliding模式无意义
+	expireAt time.Time
+}
+
+// expireCallbackEntry 是一个已注册的、以元素key为参数的失效回调及其ID
+type expireCallbackEntry struct {
+	id CallbackID
+	fn func(key interface{})
 }
 
+// ExpireMode决定一个CacheItem的lifeSpan该如何被解释
+type ExpireMode int
+
+const (
+	// ExpireSliding是默认模式: 元素在lifeSpan内未被访问就过期, 每次访问都会重置倒计时
+	ExpireSliding ExpireMode = iota
+	// ExpireAbsolute模式下元素固定在createdOn之后lifeSpan过期, 访问不会延长其寿命
+	ExpireAbsolute
+	// ExpireAt模式下元素固定在指定的时间点过期, 访问不会延长其寿命
+	ExpireAt
+)
+
 func NewCacheItem(key interface{}, data interface{}, lifeSpan time.Duration) *CacheItem {
 	t := time.Now()
 	return &CacheItem{
-		key:           key,
-		data:          data,
-		lifeSpan:      lifeSpan,
-		createdOn:     t,
-		accessedOn:    t,
-		accessCount:   0,
-		aboutToExpire: nil,
+		key:        key,
+		data:       data,
+		lifeSpan:   lifeSpan,
+		createdOn:  t,
+		accessedOn: t,
+	}
+}
+
+// NewCacheItemAbsolute创建一个固定存活期的元素, 它从创建时刻起lifeSpan后过期,
+// 即使期间被频繁访问也不会被续期
+func NewCacheItemAbsolute(key interface{}, data interface{}, lifeSpan time.Duration) *CacheItem {
+	t := time.Now()
+	return &CacheItem{
+		key:        key,
+		data:       data,
+		lifeSpan:   lifeSpan,
+		createdOn:  t,
+		accessedOn: t,
+		expireMode: ExpireAbsolute,
+		expireAt:   t.Add(lifeSpan),
+	}
+}
+
+// NewCacheItemAt创建一个在指定时间点过期的元素, 访问不会延长其寿命
+func NewCacheItemAt(key interface{}, data interface{}, deadline time.Time) *CacheItem {
+	t := time.Now()
+	return &CacheItem{
+		key:        key,
+		data:       data,
+		lifeSpan:   deadline.Sub(t),
+		createdOn:  t,
+		accessedOn: t,
+		expireMode: ExpireAt,
+		expireAt:   deadline,
 	}
 }
 
 // 标记一个元素访问时间及计数器
+// 对ExpireAbsolute/ExpireAt模式的元素, 访问不会延长其寿命, 只会增加访问计数
 func (item *CacheItem) KeepAlive() {
 	item.Lock()
 	defer item.Unlock()
-	item.accessedOn = time.Now()
+	if item.expireMode == ExpireSliding {
+		item.accessedOn = time.Now()
+	}
 	item.accessCount++
 }
 
@@ -85,8 +140,46 @@ func (item *CacheItem) Data() interface{} {
 }
 
 // 设置元素失效回调, 在元素即将从缓存中移除之前将调用它
+// 会清除之前通过SetAboutToExpireCallback或AddAboutToExpireCallback注册的所有回调
 func (item *CacheItem) SetAboutToExpireCallback(f func(interface{})) {
+	item.Lock()
+	item.aboutToExpireCallbacks = nil
+	item.Unlock()
+
+	if f != nil {
+		item.AddAboutToExpireCallback(f)
+	}
+}
+
+// AddAboutToExpireCallback 注册一个在元素即将从缓存中移除之前触发的回调
+// 返回的CallbackID可用于RemoveCallback单独移除它, 多次调用可注册多个互不覆盖的回调
+func (item *CacheItem) AddAboutToExpireCallback(f func(key interface{})) CallbackID {
 	item.Lock()
 	defer item.Unlock()
-	item.aboutToExpire = f
+
+	item.nextCallbackID++
+	id := item.nextCallbackID
+	item.aboutToExpireCallbacks = append(item.aboutToExpireCallbacks, expireCallbackEntry{id, f})
+	return id
+}
+
+// RemoveCallback 按ID移除一个之前通过AddAboutToExpireCallback注册的回调
+func (item *CacheItem) RemoveCallback(id CallbackID) {
+	item.Lock()
+	defer item.Unlock()
+
+	for i, e := range item.aboutToExpireCallbacks {
+		if e.id == id {
+			item.aboutToExpireCallbacks = append(item.aboutToExpireCallbacks[:i], item.aboutToExpireCallbacks[i+1:]...)
+			return
+		}
+	}
+}
+
+// RemoveAllCallbacks 移除元素上注册的所有失效回调
+func (item *CacheItem) RemoveAllCallbacks() {
+	item.Lock()
+	defer item.Unlock()
+
+	item.aboutToExpireCallbacks = nil
 }
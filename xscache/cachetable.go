@@ -4,49 +4,164 @@ import (
 	"log"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// CacheTable 是缓存中的表
-type CacheTable struct {
-	// 表内同步锁
+// cacheShard 是表内的一个分片, 拥有独立的锁、元素map和清理定时器
+// 将元素按key哈希分散到多个分片中, 可以让不同分片上的读写、过期检测互不阻塞,
+// 从而消除所有操作集中在单一互斥锁上的竞争
+type cacheShard struct {
 	sync.RWMutex
 
-	// 表名
-	name string
-	// 所有缓存的元素
+	// 本分片持有的元素
 	items map[interface{}]*CacheItem
 
-	// 负责触发清理的定时器
+	// 负责触发本分片清理的定时器
 	cleanupTimer *time.Timer
 	// 下次运行清理定时器时间
 	cleanupInterval time.Duration
+}
+
+func newCacheShard() *cacheShard {
+	return &cacheShard{items: make(map[interface{}]*CacheItem)}
+}
+
+// CacheTable 是缓存中的表
+type CacheTable struct {
+	// 表级元数据锁, 保护除分片数据外的配置项(回调、淘汰策略、持久化配置等)
+	sync.RWMutex
+
+	// 表名
+	name string
+	// 分片集合, 未分片的表只有一个分片, 行为与分片之前完全一致
+	shards []*cacheShard
 
 	// 当前表使用的日志记录器
 	logger *log.Logger
 
 	// 试图获取不存在元素时触发的回调方法
 	loadData func(key interface{}, args ...interface{}) *CacheItem
-	// 添加新元素时触发的回调方法
-	addedItem func(item *CacheItem)
-	// 删除元素时触发的回调方法
-	aboutToDeleteItem func(item *CacheItem)
+	// 添加新元素时触发的回调方法列表, 通过AddAddedItemCallback注册
+	addedItemCallbacks []itemCallbackEntry
+	// 删除元素时触发的回调方法列表, 通过AddAboutToDeleteItemCallback注册
+	aboutToDeleteItemCallbacks []itemCallbackEntry
+	// 下一个待分配的回调ID
+	nextCallbackID CallbackID
+
+	// 后台持久化快照器的停止信号, nil表示未启用
+	persistStop chan struct{}
+
+	// 表中允许的最大元素数量, <=0表示不限制
+	maxItems int
+	// 超出最大元素数量时使用的淘汰策略
+	evictionPolicy EvictionPolicy
+	// 淘汰策略自身不是并发安全的, 而分片引入之后多个分片可能并发触发淘汰逻辑,
+	// 因此用这个锁把所有对evictionPolicy的调用串行化
+	evictionMu sync.Mutex
+	// 元素被淘汰策略移除时触发的回调方法
+	evictionCallback func(item *CacheItem)
+
+	// 命中率、淘汰原因等统计计数器
+	stats tableStats
+}
+
+// SetCapacity 设置表中允许的最大元素数量及超出时使用的淘汰策略
+// max<=0表示不限制容量
+// 如果表中已有元素(例如刚通过LoadFromFile恢复, 或对一个运行中的表追加容量限制),
+// 这些元素会被逐一补登记到新策略中, 否则它们对policy不可见, Evict永远只能挑到
+// 此调用之后新加入的key, 旧元素就会被永久卡在表里
+func (table *CacheTable) SetCapacity(max int, policy EvictionPolicy) {
+	table.Lock()
+	table.maxItems = max
+	table.evictionPolicy = policy
+	table.Unlock()
+
+	if policy == nil {
+		return
+	}
+
+	table.evictionMu.Lock()
+	defer table.evictionMu.Unlock()
+	for _, shard := range table.shards {
+		shard.RLock()
+		for key := range shard.items {
+			policy.OnAdd(key)
+		}
+		shard.RUnlock()
+	}
+}
+
+// SetEvictionCallback 设置元素被淘汰策略移除时触发的回调函数
+func (table *CacheTable) SetEvictionCallback(f func(item *CacheItem)) {
+	table.Lock()
+	defer table.Unlock()
+	table.evictionCallback = f
+}
+
+// shardFor 返回key所归属的分片
+func (table *CacheTable) shardFor(key interface{}) *cacheShard {
+	if len(table.shards) == 1 {
+		return table.shards[0]
+	}
+	return table.shards[hashKey(key)%uint64(len(table.shards))]
+}
+
+// evictIfOverCapacity 在表中元素总数(所有分片之和)超出maxItems时, 通过淘汰策略
+// 选择一个元素, 并按照与TTL过期相同的回调路径(aboutToDeleteItem/aboutToExpire)将其移除
+func (table *CacheTable) evictIfOverCapacity() {
+	table.RLock()
+	maxItems := table.maxItems
+	policy := table.evictionPolicy
+	table.RUnlock()
+
+	if maxItems <= 0 || policy == nil || table.Count() <= maxItems {
+		return
+	}
+
+	table.evictionMu.Lock()
+	key, ok := policy.Evict()
+	table.evictionMu.Unlock()
+	if !ok {
+		return
+	}
+
+	shard := table.shardFor(key)
+	shard.Lock()
+	item, err := table.deleteInternal(shard, key, DeleteReasonEvicted)
+	shard.Unlock()
+	if err != nil {
+		return
+	}
+
+	table.RLock()
+	cb := table.evictionCallback
+	table.RUnlock()
+
+	if cb != nil {
+		cb(item)
+	}
 }
 
 // 统计当前表中元素总数
 func (table *CacheTable) Count() int {
-	table.RLock()
-	defer table.RUnlock()
-	return len(table.items)
+	total := 0
+	for _, shard := range table.shards {
+		shard.RLock()
+		total += len(shard.items)
+		shard.RUnlock()
+	}
+	return total
 }
 
 // 遍历所有元素
 func (table *CacheTable) Foreach(trans func(key interface{}, item *CacheItem)) {
-	table.RLock()
-	defer table.RUnlock()
-
-	for k, v := range table.items {
-		trans(k, v)
+	for _, shard := range table.shards {
+		shard.RLock()
+		for k, v := range shard.items {
+			trans(k, v)
+		}
+		shard.RUnlock()
 	}
 }
 
@@ -57,18 +172,26 @@ func (table *CacheTable) SetDataLoader(f func(interface{}, ...interface{}) *Cach
 	table.loadData = f
 }
 
-// 设置新增元素时回调函数
+// 设置新增元素时回调函数, 会清除之前通过SetAddedItemCallback或AddAddedItemCallback注册的所有回调
 func (table *CacheTable) SetAddedItemCallback(f func(*CacheItem)) {
 	table.Lock()
-	defer table.Unlock()
-	table.addedItem = f
+	table.addedItemCallbacks = nil
+	table.Unlock()
+
+	if f != nil {
+		table.AddAddedItemCallback(f)
+	}
 }
 
-// 设置删除元素时回调函数
+// 设置删除元素时回调函数, 会清除之前通过SetAboutToDeleteItemCallback或AddAboutToDeleteItemCallback注册的所有回调
 func (table *CacheTable) SetAboutToDeleteItemCallback(f func(*CacheItem)) {
 	table.Lock()
-	defer table.Unlock()
-	table.aboutToDeleteItem = f
+	table.aboutToDeleteItemCallbacks = nil
+	table.Unlock()
+
+	if f != nil {
+		table.AddAboutToDeleteItemCallback(f)
+	}
 }
 
 // 设置日志
@@ -83,134 +206,206 @@ func (table *CacheTable) Add(key interface{}, data interface{}, lifeSpan time.Du
 	item := NewCacheItem(key, data, lifeSpan)
 
 	// 添加元素到缓存
-	table.Lock()
-	table.addInternal(item)
+	shard := table.shardFor(key)
+	shard.Lock()
+	table.addInternal(shard, item)
 
 	return item
 }
 
-// 过期检测, 自动调节定时器触发
-func (table *CacheTable) expirationCheck() {
-	table.Lock()
-	if table.cleanupTimer != nil {
-		table.cleanupTimer.Stop()
+// AddAbsolute添加一个固定存活期的元素, 它从创建时刻起lifeSpan后过期, 不受访问续期影响
+func (table *CacheTable) AddAbsolute(key interface{}, data interface{}, lifeSpan time.Duration) *CacheItem {
+	item := NewCacheItemAbsolute(key, data, lifeSpan)
+
+	shard := table.shardFor(key)
+	shard.Lock()
+	table.addInternal(shard, item)
+
+	return item
+}
+
+// AddWithDeadline添加一个在指定时间点过期的元素, 不受访问续期影响
+func (table *CacheTable) AddWithDeadline(key interface{}, data interface{}, deadline time.Time) *CacheItem {
+	item := NewCacheItemAt(key, data, deadline)
+
+	shard := table.shardFor(key)
+	shard.Lock()
+	table.addInternal(shard, item)
+
+	return item
+}
+
+// 过期检测, 自动调节定时器触发, 每个分片独立运行
+func (table *CacheTable) expirationCheck(shard *cacheShard) {
+	shard.Lock()
+	if shard.cleanupTimer != nil {
+		shard.cleanupTimer.Stop()
 	}
-	if table.cleanupInterval > 0 {
-		table.log("Expiration check triggered after", table.cleanupInterval, "for table", table.name)
+	if shard.cleanupInterval > 0 {
+		table.log("Expiration check triggered after", shard.cleanupInterval, "for table", table.name)
 	} else {
 		table.log("Expiration check intalled for table", table.name)
 	}
 
 	now := time.Now()
 	smallestDuration := 0 * time.Second // 最小持续时间(最近过期时间)
-	for key, item := range table.items {
+	for key, item := range shard.items {
 		item.RLock()
 		lifeSpan := item.lifeSpan
+		expireMode := item.expireMode
 		accessedOn := item.accessedOn
+		expireAt := item.expireAt
 		item.RUnlock()
 
 		if lifeSpan == 0 {
 			continue
 		}
-		if now.Sub(accessedOn) >= lifeSpan { // 当前时间 - 最近访问时间 >= 过期时间
+
+		// ExpireSliding按最近一次访问时间计算剩余寿命, 访问会重置倒计时;
+		// ExpireAbsolute/ExpireAt按固定的过期时间点计算, 不受访问影响
+		var remaining time.Duration
+		if expireMode == ExpireSliding {
+			remaining = lifeSpan - now.Sub(accessedOn)
+		} else {
+			remaining = expireAt.Sub(now)
+		}
+
+		if remaining <= 0 {
 			// 元素已过期
-			table.deleteInternal(key)
+			table.deleteInternal(shard, key, DeleteReasonExpired)
 		} else {
 			// 还未过期, 按时间顺序找到最接近生命周期结束的元素
-			// 过期时间 - (当前时间 - 最近访问时间) < 最近过期时间
-			if smallestDuration == 0 || lifeSpan-now.Sub(accessedOn) < smallestDuration {
-				smallestDuration = lifeSpan - now.Sub(accessedOn) // 下一个即将过期的元素
+			if smallestDuration == 0 || remaining < smallestDuration {
+				smallestDuration = remaining // 下一个即将过期的元素
 			}
 		}
 	}
 
 	// 设置下一次运行清理方法的时间
-	table.cleanupInterval = smallestDuration
+	shard.cleanupInterval = smallestDuration
 	if smallestDuration > 0 {
-		table.cleanupTimer = time.AfterFunc(smallestDuration, func() {
-			go table.expirationCheck()
+		shard.cleanupTimer = time.AfterFunc(smallestDuration, func() {
+			go table.expirationCheck(shard)
 		})
 	}
 
-	table.Unlock()
+	shard.Unlock()
 }
 
-func (table *CacheTable) addInternal(item *CacheItem) {
-	// 注意: 除非表互斥锁被锁定，否则不要运行此方法！
+func (table *CacheTable) addInternal(shard *cacheShard, item *CacheItem) {
+	// 注意: 除非分片互斥锁被锁定，否则不要运行此方法！
 	// 这将打开它的调用者的回调和运行前的检查
 	table.log("Adding item with key", item.key, "and lifespan of", item.lifeSpan, "to table", table.name)
-	table.items[item.key] = item
+	shard.items[item.key] = item
+	atomic.AddInt64(&table.stats.adds, 1)
 
-	expDur := table.cleanupInterval
-	addedItem := table.addedItem
-	table.Unlock()
+	expDur := shard.cleanupInterval
+	shard.Unlock()
+
+	table.RLock()
+	addedItemCallbacks := append([]itemCallbackEntry(nil), table.addedItemCallbacks...)
+	policy := table.evictionPolicy
+	table.RUnlock()
+
+	// 通知淘汰策略有新元素加入
+	if policy != nil {
+		table.evictionMu.Lock()
+		policy.OnAdd(item.key)
+		table.evictionMu.Unlock()
+	}
 
 	// 执行添加元素回调函数
-	if addedItem != nil {
-		addedItem(item)
+	for _, cb := range addedItemCallbacks {
+		cb.fn(item)
 	}
 
-	// 如果设置了失效时间, 并且定时器等于0或设置的失效时间小于定时器, 执行失效检测
-	if item.lifeSpan > 0 && (expDur == 0 || item.lifeSpan < expDur) {
-		table.expirationCheck()
+	// 如果设置了失效时间, 并且定时器等于0或剩余存活时间小于定时器, 执行失效检测
+	// ExpireAbsolute/ExpireAt模式下用expireAt计算剩余时间, 而不是原始lifeSpan:
+	// 这两种模式的lifeSpan在deadline已经过去时是负数, 若仍按item.lifeSpan>0
+	// 判断, 插入时已经过期的元素会被直接跳过调度, 永远不会被清理;
+	// lifeSpan恰好为0的元素在任何模式下都按不过期处理, expirationCheck会跳过它们
+	if item.expireMode == ExpireSliding {
+		if item.lifeSpan > 0 && (expDur == 0 || item.lifeSpan < expDur) {
+			table.expirationCheck(shard)
+		}
+	} else if remaining := item.expireAt.Sub(time.Now()); expDur == 0 || remaining < expDur {
+		table.expirationCheck(shard)
 	}
+
+	// 如果设置了容量上限且已超出, 淘汰一个元素
+	table.evictIfOverCapacity()
 }
 
-func (table *CacheTable) deleteInternal(key interface{}) (*CacheItem, error) {
-	r, ok := table.items[key]
+func (table *CacheTable) deleteInternal(shard *cacheShard, key interface{}, reason DeleteReason) (*CacheItem, error) {
+	r, ok := shard.items[key]
 	if !ok {
 		return nil, ErrKeyNotFound
 	}
 
-	aboutToDeleteItem := table.aboutToDeleteItem
-	table.Unlock()
+	table.RLock()
+	aboutToDeleteItemCallbacks := append([]itemCallbackEntry(nil), table.aboutToDeleteItemCallbacks...)
+	policy := table.evictionPolicy
+	table.RUnlock()
+	shard.Unlock()
 
 	// 执行删除前元素回调函数
-	if aboutToDeleteItem != nil {
-		aboutToDeleteItem(r)
+	for _, cb := range aboutToDeleteItemCallbacks {
+		cb.fn(r)
 	}
 
 	r.RLock()
-	defer r.RUnlock()
-	if r.aboutToExpire != nil {
-		r.aboutToExpire(key)
+	aboutToExpireCallbacks := append([]expireCallbackEntry(nil), r.aboutToExpireCallbacks...)
+	createdOn := r.createdOn
+	accessCount := r.accessCount
+	r.RUnlock()
+	for _, cb := range aboutToExpireCallbacks {
+		cb.fn(key)
 	}
 
-	table.Lock()
-	table.log("Deleting item with key", key, "created on", r.createdOn, "and hit", r.accessCount, "items from table", table.name)
-	delete(table.items, key)
+	shard.Lock()
+	table.log("Deleting item with key", key, "created on", createdOn, "and hit", accessCount, "items from table", table.name)
+	delete(shard.items, key)
+	if policy != nil {
+		table.evictionMu.Lock()
+		policy.OnDelete(key)
+		table.evictionMu.Unlock()
+	}
+	table.stats.recordDelete(reason, 1)
 
 	return r, nil
 }
 
 // 从缓存中删除元素
 func (table *CacheTable) Delete(key interface{}) (*CacheItem, error) {
-	table.Lock()
-	defer table.Unlock()
+	shard := table.shardFor(key)
+	shard.Lock()
+	defer shard.Unlock()
 
-	return table.deleteInternal(key)
+	return table.deleteInternal(shard, key, DeleteReasonExplicit)
 }
 
 // 判断元素是否存在
 func (table *CacheTable) Exists(key interface{}) bool {
-	table.RLock()
-	defer table.RUnlock()
-	_, ok := table.items[key]
+	shard := table.shardFor(key)
+	shard.RLock()
+	defer shard.RUnlock()
+	_, ok := shard.items[key]
 
 	return ok
 }
 
 // 如果元素不存在则添加元素
 func (table *CacheTable) NotFoundAdd(key interface{}, data interface{}, lifeSpan time.Duration) bool {
-	table.Lock()
+	shard := table.shardFor(key)
+	shard.Lock()
 
-	if _, ok := table.items[key]; ok {
-		table.Unlock()
+	if _, ok := shard.items[key]; ok {
+		shard.Unlock()
 		return false
 	}
 
 	item := NewCacheItem(key, data, lifeSpan)
-	table.addInternal(item)
+	table.addInternal(shard, item)
 
 	return true
 }
@@ -218,24 +413,47 @@ func (table *CacheTable) NotFoundAdd(key interface{}, data interface{}, lifeSpan
 // 从缓存中返回一个元素, 并将其标记为保存
 // 还可以通过额外的参数传递到dataloader回调函数
 func (table *CacheTable) Value(key interface{}, args ...interface{}) (*CacheItem, error) {
+	shard := table.shardFor(key)
+	shard.RLock()
+	r, ok := shard.items[key]
+	shard.RUnlock()
+
 	table.RLock()
-	r, ok := table.items[key]
 	loadData := table.loadData
+	policy := table.evictionPolicy
 	table.RUnlock()
 
 	if ok {
 		// 更新访问计数和时间
+		atomic.AddInt64(&table.stats.hits, 1)
 		r.KeepAlive()
+		if policy != nil {
+			table.evictionMu.Lock()
+			policy.OnAccess(key)
+			table.evictionMu.Unlock()
+		}
 		return r, nil
 	}
+	atomic.AddInt64(&table.stats.misses, 1)
 
 	// 元素不存在, 尝试使用数据加载器来获取
 	if loadData != nil {
 		item := loadData(key, args...)
 		if item != nil {
-			table.Add(key, item.data, item.lifeSpan)
+			atomic.AddInt64(&table.stats.loadHits, 1)
+			// 按加载器返回的元素原本的过期模式重新加入, 否则ExpireAbsolute/ExpireAt
+			// 元素会被table.Add降级成ExpireSliding, 悄悄丢掉加载器设置的过期模式
+			switch item.expireMode {
+			case ExpireAbsolute:
+				table.AddAbsolute(key, item.data, item.lifeSpan)
+			case ExpireAt:
+				table.AddWithDeadline(key, item.data, item.expireAt)
+			default:
+				table.Add(key, item.data, item.lifeSpan)
+			}
 			return item, nil
 		}
+		atomic.AddInt64(&table.stats.loadMisses, 1)
 
 		return nil, ErrKeyNotFoundOrLoadable
 	}
@@ -245,14 +463,31 @@ func (table *CacheTable) Value(key interface{}, args ...interface{}) (*CacheItem
 
 // 清空当前表
 func (table *CacheTable) Flush() {
-	table.Lock()
-	defer table.Unlock()
+	table.RLock()
+	policy := table.evictionPolicy
+	table.RUnlock()
 
-	table.log("Flushing table", table.name)
+	for _, shard := range table.shards {
+		shard.Lock()
+		table.log("Flushing table", table.name)
+
+		// 被清空的key也要从淘汰策略中移除, 否则策略里残留的过期key之后被
+		// Evict()选中时, deleteInternal在shard.items里已经找不到它,
+		// evictIfOverCapacity会直接返回, 容量限制就永久失效了
+		if policy != nil {
+			table.evictionMu.Lock()
+			for key := range shard.items {
+				policy.OnDelete(key)
+			}
+			table.evictionMu.Unlock()
+		}
 
-	table.items = make(map[interface{}]*CacheItem)
-	if table.cleanupTimer != nil {
-		table.cleanupTimer.Stop()
+		table.stats.recordDelete(DeleteReasonFlushed, int64(len(shard.items)))
+		shard.items = make(map[interface{}]*CacheItem)
+		if shard.cleanupTimer != nil {
+			shard.cleanupTimer.Stop()
+		}
+		shard.Unlock()
 	}
 }
 
@@ -270,16 +505,18 @@ func (p CacheItemPairList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 func (p CacheItemPairList) Less(i, j int) bool { return p[i].AccessCount > p[j].AccessCount }
 func (p CacheItemPairList) Len() int           { return len(p) }
 
-// 返回最常用的元素
+// 返回最常用的元素, 跨所有分片汇总后排序
 func (table *CacheTable) MostAccessed(count int64) []*CacheItem {
-	table.RLock()
-	defer table.RUnlock()
-
-	p := make(CacheItemPairList, len(table.items))
-	i := 0
-	for k, v := range table.items {
-		p[i] = CacheItemPair{k, v.accessCount}
-		i++
+	items := make(map[interface{}]*CacheItem)
+	var p CacheItemPairList
+
+	for _, shard := range table.shards {
+		shard.RLock()
+		for k, v := range shard.items {
+			p = append(p, CacheItemPair{k, v.accessCount})
+			items[k] = v
+		}
+		shard.RUnlock()
 	}
 	sort.Sort(p)
 
@@ -290,7 +527,7 @@ func (table *CacheTable) MostAccessed(count int64) []*CacheItem {
 			break
 		}
 
-		item, ok := table.items[v.Key]
+		item, ok := items[v.Key]
 		if ok {
 			r = append(r, item)
 		}
@@ -0,0 +1,60 @@
+package xscache
+
+// CallbackID 标识一个已注册的回调, 用于之后将其单独移除
+type CallbackID uint64
+
+// itemCallbackEntry 是一个已注册的、以*CacheItem为参数的回调及其ID
+type itemCallbackEntry struct {
+	id CallbackID
+	fn func(item *CacheItem)
+}
+
+// AddAddedItemCallback 注册一个在新元素加入表时触发的回调, 返回的CallbackID可用于RemoveCallback单独移除它
+// 多次调用可注册多个回调, 它们都会被触发, 互不覆盖
+func (table *CacheTable) AddAddedItemCallback(f func(item *CacheItem)) CallbackID {
+	table.Lock()
+	defer table.Unlock()
+
+	table.nextCallbackID++
+	id := table.nextCallbackID
+	table.addedItemCallbacks = append(table.addedItemCallbacks, itemCallbackEntry{id, f})
+	return id
+}
+
+// AddAboutToDeleteItemCallback 注册一个在元素即将从表中移除前触发的回调, 返回的CallbackID可用于RemoveCallback单独移除它
+func (table *CacheTable) AddAboutToDeleteItemCallback(f func(item *CacheItem)) CallbackID {
+	table.Lock()
+	defer table.Unlock()
+
+	table.nextCallbackID++
+	id := table.nextCallbackID
+	table.aboutToDeleteItemCallbacks = append(table.aboutToDeleteItemCallbacks, itemCallbackEntry{id, f})
+	return id
+}
+
+// RemoveCallback 按ID移除一个之前通过AddAddedItemCallback或AddAboutToDeleteItemCallback注册的回调
+func (table *CacheTable) RemoveCallback(id CallbackID) {
+	table.Lock()
+	defer table.Unlock()
+
+	table.addedItemCallbacks = removeItemCallback(table.addedItemCallbacks, id)
+	table.aboutToDeleteItemCallbacks = removeItemCallback(table.aboutToDeleteItemCallbacks, id)
+}
+
+// RemoveAllCallbacks 移除表上注册的所有added/aboutToDeleteItem回调
+func (table *CacheTable) RemoveAllCallbacks() {
+	table.Lock()
+	defer table.Unlock()
+
+	table.addedItemCallbacks = nil
+	table.aboutToDeleteItemCallbacks = nil
+}
+
+func removeItemCallback(entries []itemCallbackEntry, id CallbackID) []itemCallbackEntry {
+	for i, e := range entries {
+		if e.id == id {
+			return append(entries[:i], entries[i+1:]...)
+		}
+	}
+	return entries
+}
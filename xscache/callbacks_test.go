@@ -0,0 +1,52 @@
+package xscache
+
+import "testing"
+
+func TestMultipleAddedItemCallbacksAllFire(t *testing.T) {
+	table := newCacheTable("callbacks-added", 1)
+
+	var firstCalls, secondCalls int
+	table.AddAddedItemCallback(func(item *CacheItem) { firstCalls++ })
+	table.AddAddedItemCallback(func(item *CacheItem) { secondCalls++ })
+
+	table.Add("key", "value", 0)
+
+	if firstCalls != 1 || secondCalls != 1 {
+		t.Errorf("firstCalls = %d, secondCalls = %d, want 1 and 1", firstCalls, secondCalls)
+	}
+}
+
+func TestRemoveCallbackDeregistersOnlyThatCallback(t *testing.T) {
+	table := newCacheTable("callbacks-remove", 1)
+
+	var firstCalls, secondCalls int
+	id := table.AddAddedItemCallback(func(item *CacheItem) { firstCalls++ })
+	table.AddAddedItemCallback(func(item *CacheItem) { secondCalls++ })
+
+	table.RemoveCallback(id)
+	table.Add("key", "value", 0)
+
+	if firstCalls != 0 {
+		t.Errorf("firstCalls = %d, want 0 after RemoveCallback", firstCalls)
+	}
+	if secondCalls != 1 {
+		t.Errorf("secondCalls = %d, want 1", secondCalls)
+	}
+}
+
+func TestRemoveAllCallbacksClearsBothLists(t *testing.T) {
+	table := newCacheTable("callbacks-remove-all", 1)
+
+	var addedCalls, deleteCalls int
+	table.AddAddedItemCallback(func(item *CacheItem) { addedCalls++ })
+	table.AddAboutToDeleteItemCallback(func(item *CacheItem) { deleteCalls++ })
+
+	table.RemoveAllCallbacks()
+
+	table.Add("key", "value", 0)
+	table.Delete("key")
+
+	if addedCalls != 0 || deleteCalls != 0 {
+		t.Errorf("addedCalls = %d, deleteCalls = %d, want 0 and 0 after RemoveAllCallbacks", addedCalls, deleteCalls)
+	}
+}
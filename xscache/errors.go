@@ -0,0 +1,9 @@
+package xscache
+
+import "errors"
+
+// ErrKeyNotFound 表示要查找的key在表中不存在; Value只在没有注册数据加载器时返回它, Delete对不存在的key总是返回它
+var ErrKeyNotFound = errors.New("xscache: key not found")
+
+// ErrKeyNotFoundOrLoadable 表示要查找的key在表中不存在, 且数据加载器也未能为其加载到数据
+var ErrKeyNotFoundOrLoadable = errors.New("xscache: key not found and could not be loaded")
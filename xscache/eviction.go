@@ -0,0 +1,144 @@
+package xscache
+
+import "container/list"
+
+// EvictionPolicy 是可插拔的缓存淘汰策略
+// 包括Evict在内, 所有方法都在表的evictionMu已被持有的情况下串行调用, 实现本身无需加锁
+type EvictionPolicy interface {
+	// OnAdd 在元素被加入表后调用
+	OnAdd(key interface{})
+	// OnAccess 在元素被访问(命中)后调用
+	OnAccess(key interface{})
+	// OnDelete 在元素从表中被移除后调用, 无论是TTL过期、显式删除还是被淘汰
+	OnDelete(key interface{})
+	// Evict 挑选一个应被淘汰的元素并返回其key, 策略内没有可淘汰的元素时ok为false
+	Evict() (key interface{}, ok bool)
+}
+
+// LRUPolicy 是最近最少使用淘汰策略, 用双向链表维护访问顺序, 链表尾部是下一个淘汰对象
+type LRUPolicy struct {
+	list  *list.List
+	elems map[interface{}]*list.Element
+}
+
+// NewLRUPolicy 创建一个LRU淘汰策略
+func NewLRUPolicy() *LRUPolicy {
+	return &LRUPolicy{
+		list:  list.New(),
+		elems: make(map[interface{}]*list.Element),
+	}
+}
+
+func (p *LRUPolicy) OnAdd(key interface{}) {
+	if e, ok := p.elems[key]; ok {
+		p.list.MoveToFront(e)
+		return
+	}
+	p.elems[key] = p.list.PushFront(key)
+}
+
+func (p *LRUPolicy) OnAccess(key interface{}) {
+	if e, ok := p.elems[key]; ok {
+		p.list.MoveToFront(e)
+	}
+}
+
+func (p *LRUPolicy) OnDelete(key interface{}) {
+	if e, ok := p.elems[key]; ok {
+		p.list.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *LRUPolicy) Evict() (interface{}, bool) {
+	e := p.list.Back()
+	if e == nil {
+		return nil, false
+	}
+	p.list.Remove(e)
+	delete(p.elems, e.Value)
+	return e.Value, true
+}
+
+// FIFOPolicy 是先进先出淘汰策略, 访问不影响淘汰顺序
+type FIFOPolicy struct {
+	list  *list.List
+	elems map[interface{}]*list.Element
+}
+
+// NewFIFOPolicy 创建一个FIFO淘汰策略
+func NewFIFOPolicy() *FIFOPolicy {
+	return &FIFOPolicy{
+		list:  list.New(),
+		elems: make(map[interface{}]*list.Element),
+	}
+}
+
+func (p *FIFOPolicy) OnAdd(key interface{}) {
+	if _, ok := p.elems[key]; ok {
+		return
+	}
+	p.elems[key] = p.list.PushBack(key)
+}
+
+func (p *FIFOPolicy) OnAccess(key interface{}) {}
+
+func (p *FIFOPolicy) OnDelete(key interface{}) {
+	if e, ok := p.elems[key]; ok {
+		p.list.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *FIFOPolicy) Evict() (interface{}, bool) {
+	e := p.list.Front()
+	if e == nil {
+		return nil, false
+	}
+	p.list.Remove(e)
+	delete(p.elems, e.Value)
+	return e.Value, true
+}
+
+// LFUPolicy 是最不经常使用淘汰策略, 复用每个key被访问的次数, 淘汰访问次数最小的元素
+type LFUPolicy struct {
+	counts map[interface{}]int64
+}
+
+// NewLFUPolicy 创建一个LFU淘汰策略
+func NewLFUPolicy() *LFUPolicy {
+	return &LFUPolicy{counts: make(map[interface{}]int64)}
+}
+
+func (p *LFUPolicy) OnAdd(key interface{}) {
+	if _, ok := p.counts[key]; !ok {
+		p.counts[key] = 0
+	}
+}
+
+func (p *LFUPolicy) OnAccess(key interface{}) {
+	p.counts[key]++
+}
+
+func (p *LFUPolicy) OnDelete(key interface{}) {
+	delete(p.counts, key)
+}
+
+func (p *LFUPolicy) Evict() (interface{}, bool) {
+	var (
+		minKey   interface{}
+		minCount int64
+		found    bool
+	)
+	for k, c := range p.counts {
+		if !found || c < minCount {
+			minKey, minCount, found = k, c, true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	delete(p.counts, minKey)
+	return minKey, true
+}
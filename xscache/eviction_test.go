@@ -0,0 +1,65 @@
+package xscache
+
+import "testing"
+
+func TestSetCapacityBackfillsExistingItems(t *testing.T) {
+	table := newCacheTable("eviction-backfill", 1)
+	originals := []string{"a", "b", "c", "d", "e"}
+	for i, k := range originals {
+		table.Add(k, i, 0)
+	}
+
+	table.SetCapacity(3, NewLRUPolicy())
+
+	for i, k := range []string{"x", "y", "z"} {
+		table.Add(k, i, 0)
+	}
+
+	remainingOriginals := 0
+	for _, k := range originals {
+		if table.Exists(k) {
+			remainingOriginals++
+		}
+	}
+	if remainingOriginals == len(originals) {
+		t.Error("all pre-existing items survived capacity enforcement: SetCapacity did not backfill the eviction policy with them")
+	}
+}
+
+func TestLRUPolicyEvictsLeastRecentlyUsed(t *testing.T) {
+	table := newCacheTable("eviction-lru", 1)
+	table.SetCapacity(2, NewLRUPolicy())
+
+	table.Add("a", 1, 0)
+	table.Add("b", 2, 0)
+	table.Value("a") // touch "a" so "b" becomes the least recently used
+	table.Add("c", 3, 0)
+
+	if table.Exists("b") {
+		t.Error("Exists(\"b\") = true, want false: least recently used item should have been evicted")
+	}
+	if !table.Exists("a") || !table.Exists("c") {
+		t.Error("expected \"a\" and \"c\" to remain after eviction")
+	}
+}
+
+func TestFlushClearsEvictionPolicyState(t *testing.T) {
+	table := newCacheTable("eviction-flush", 1)
+	table.SetCapacity(2, NewLRUPolicy())
+
+	table.Add("a", 1, 0)
+	table.Add("b", 2, 0)
+
+	table.Flush()
+
+	table.Add("x", 1, 0)
+	table.Add("y", 2, 0)
+	table.Add("z", 3, 0) // over capacity, must evict "x" or "y", not a stale pre-flush key
+
+	if got := table.Count(); got != 2 {
+		t.Fatalf("Count() = %d, want 2 after exceeding capacity post-flush", got)
+	}
+	if table.Exists("a") || table.Exists("b") {
+		t.Error("pre-flush keys should not still be tracked by the eviction policy")
+	}
+}
@@ -0,0 +1,51 @@
+package xscache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAddWithPastDeadlineIsCleanedUp 验证插入时deadline已经过去的元素会被主动清理,
+// 而不是在没有其他定时器触发的情况下永远留在表里
+func TestAddWithPastDeadlineIsCleanedUp(t *testing.T) {
+	table := newCacheTable("expiration-past-deadline", 1)
+	table.AddWithDeadline("stale", "value", time.Now().Add(-1*time.Hour))
+
+	time.Sleep(20 * time.Millisecond)
+
+	if table.Exists("stale") {
+		t.Error("Exists(\"stale\") = true, want false: already-expired deadline item should have been cleaned up")
+	}
+}
+
+func TestAddAbsoluteWithNegativeLifeSpanIsCleanedUp(t *testing.T) {
+	table := newCacheTable("expiration-past-absolute", 1)
+	table.AddAbsolute("stale", "value", -1*time.Second)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if table.Exists("stale") {
+		t.Error("Exists(\"stale\") = true, want false: already-expired absolute item should have been cleaned up")
+	}
+}
+
+// TestDataLoaderPreservesAbsoluteExpireMode 验证当数据加载器返回的元素使用
+// ExpireAbsolute/ExpireAt模式时, Value()的加载回填路径不会把它降级为ExpireSliding
+func TestDataLoaderPreservesAbsoluteExpireMode(t *testing.T) {
+	table := newCacheTable("expiration-loader-absolute", 1)
+	table.SetDataLoader(func(key interface{}, args ...interface{}) *CacheItem {
+		return NewCacheItemAbsolute(key, "loaded", 30*time.Millisecond)
+	})
+
+	if _, err := table.Value("key"); err != nil {
+		t.Fatalf("Value(\"key\") error = %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	table.Value("key") // if this were downgraded to ExpireSliding, this access would reset the countdown
+	time.Sleep(25 * time.Millisecond)
+
+	if table.Exists("key") {
+		t.Error("Exists(\"key\") = true, want false: absolute-mode item loaded via the data loader should still expire on its original fixed schedule, not be extended by the second access")
+	}
+}
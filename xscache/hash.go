@@ -0,0 +1,45 @@
+package xscache
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// hashKey 为任意可比较的key计算一个稳定的哈希值, 用于在分片表中选择目标分片
+// string/整型有专门的快速路径, 其余类型退化为对其字符串表示取fnv哈希
+// 注意: key最终会作为shard.items这个map的键使用, 因此不支持[]byte等不可比较类型;
+// 需要以字节序列作为key的调用方应自行转换为string
+func hashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return fnv64a(k)
+	case int:
+		return uint64(k)
+	case int8:
+		return uint64(k)
+	case int16:
+		return uint64(k)
+	case int32:
+		return uint64(k)
+	case int64:
+		return uint64(k)
+	case uint:
+		return uint64(k)
+	case uint8:
+		return uint64(k)
+	case uint16:
+		return uint64(k)
+	case uint32:
+		return uint64(k)
+	case uint64:
+		return k
+	default:
+		return fnv64a(fmt.Sprint(key))
+	}
+}
+
+func fnv64a(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
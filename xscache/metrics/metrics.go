@@ -0,0 +1,45 @@
+// Package metrics导出xscache.CacheTable的统计数据到prometheus
+// 它被拆分为独立的子包, 以便核心xscache包不必依赖prometheus客户端库
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"xscache"
+)
+
+// Register 为table的Stats()创建一组prometheus gauge并注册到registerer,
+// 每次采集时都会重新读取table当前的统计快照
+func Register(table *xscache.CacheTable, registerer prometheus.Registerer, labels prometheus.Labels) error {
+	gauges := []struct {
+		name  string
+		help  string
+		value func(xscache.Stats) float64
+	}{
+		{"xscache_hits_total", "Number of Value() calls that found the key in cache.", func(s xscache.Stats) float64 { return float64(s.Hits) }},
+		{"xscache_misses_total", "Number of Value() calls that did not find the key in cache.", func(s xscache.Stats) float64 { return float64(s.Misses) }},
+		{"xscache_load_hits_total", "Number of data loader invocations that returned an item.", func(s xscache.Stats) float64 { return float64(s.LoadHits) }},
+		{"xscache_load_misses_total", "Number of data loader invocations that returned nil.", func(s xscache.Stats) float64 { return float64(s.LoadMisses) }},
+		{"xscache_adds_total", "Number of items added to the table.", func(s xscache.Stats) float64 { return float64(s.Adds) }},
+		{"xscache_deletes_explicit_total", "Number of items removed via Delete.", func(s xscache.Stats) float64 { return float64(s.DeletesExplicit) }},
+		{"xscache_deletes_expired_total", "Number of items removed due to TTL expiration.", func(s xscache.Stats) float64 { return float64(s.DeletesExpired) }},
+		{"xscache_deletes_evicted_total", "Number of items removed by the eviction policy.", func(s xscache.Stats) float64 { return float64(s.DeletesEvicted) }},
+		{"xscache_deletes_flushed_total", "Number of items removed via Flush.", func(s xscache.Stats) float64 { return float64(s.DeletesFlushed) }},
+		{"xscache_size", "Current number of items in the table.", func(s xscache.Stats) float64 { return float64(s.Size) }},
+	}
+
+	for _, g := range gauges {
+		g := g
+		collector := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        g.name,
+			Help:        g.help,
+			ConstLabels: labels,
+		}, func() float64 { return g.value(table.Stats()) })
+
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
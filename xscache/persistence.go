@@ -0,0 +1,165 @@
+package xscache
+
+import (
+	"encoding/gob"
+	"os"
+	"time"
+)
+
+// cacheItemSnapshot 是CacheItem可被gob编码的快照视图
+// CacheItem本身内嵌了sync.RWMutex且可能携带函数类型的回调, 两者都无法被gob编码,
+// 因此落盘与恢复时一律通过该DTO中转
+type cacheItemSnapshot struct {
+	Key         interface{}
+	Data        interface{}
+	LifeSpan    time.Duration
+	CreatedOn   time.Time
+	AccessedOn  time.Time
+	AccessCount int64
+	ExpireMode  ExpireMode
+	ExpireAt    time.Time
+}
+
+// snapshotItems 在持有每个分片读锁的情况下拷贝出表中所有元素的可序列化视图
+func (table *CacheTable) snapshotItems() []cacheItemSnapshot {
+	var snapshots []cacheItemSnapshot
+
+	for _, shard := range table.shards {
+		shard.RLock()
+		for _, item := range shard.items {
+			item.RLock()
+			snapshots = append(snapshots, cacheItemSnapshot{
+				Key:         item.key,
+				Data:        item.data,
+				LifeSpan:    item.lifeSpan,
+				CreatedOn:   item.createdOn,
+				AccessedOn:  item.accessedOn,
+				AccessCount: item.accessCount,
+				ExpireMode:  item.expireMode,
+				ExpireAt:    item.expireAt,
+			})
+			item.RUnlock()
+		}
+		shard.RUnlock()
+	}
+
+	return snapshots
+}
+
+// SaveToFile 将当前表中所有元素以gob格式编码并写入指定文件
+// 元素中的data如果是调用方自定义的类型, 必须提前通过gob.Register注册该类型,
+// 否则编码会失败; aboutToExpire等回调函数不会被持久化
+func (table *CacheTable) SaveToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(table.snapshotItems())
+}
+
+// LoadFromFile 从指定文件恢复表中的元素, 与当前表中已有的同名key会被覆盖
+// 恢复时ExpireSliding元素按 lifeSpan - (now - accessedOn) 计算剩余存活时间,
+// ExpireAbsolute/ExpireAt元素按expireAt判断, 已过期的元素会被丢弃
+// 回调函数不会被恢复, 调用方需要在LoadFromFile之后自行重新绑定
+func (table *CacheTable) LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var snapshots []cacheItemSnapshot
+	if err := gob.NewDecoder(f).Decode(&snapshots); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	touched := make(map[*cacheShard]bool)
+	for _, s := range snapshots {
+		if s.LifeSpan == 0 {
+			// 不过期, 直接恢复
+		} else if s.ExpireMode == ExpireSliding {
+			if s.LifeSpan-now.Sub(s.AccessedOn) <= 0 {
+				// 已过期, 不恢复
+				continue
+			}
+		} else if !s.ExpireAt.After(now) {
+			// 已过期, 不恢复
+			continue
+		}
+
+		shard := table.shardFor(s.Key)
+		item := &CacheItem{
+			key:         s.Key,
+			data:        s.Data,
+			lifeSpan:    s.LifeSpan,
+			createdOn:   s.CreatedOn,
+			accessedOn:  s.AccessedOn,
+			accessCount: s.AccessCount,
+			expireMode:  s.ExpireMode,
+			expireAt:    s.ExpireAt,
+		}
+
+		// 通过addInternal恢复, 而不是直接写入shard.items, 这样淘汰策略才能
+		// 通过OnAdd得知这些key的存在, stats.adds也会正确计数
+		shard.Lock()
+		table.addInternal(shard, item)
+		touched[shard] = true
+	}
+
+	for shard := range touched {
+		table.expirationCheck(shard)
+	}
+	return nil
+}
+
+// SetPersistence 启动一个后台快照器, 每隔interval将表内容flush到path
+// flush期间仅持有RLock, 先写入临时文件再原子重命名, 避免其他进程读到写了一半的文件
+// 重复调用会先停止上一个快照器再启动新的
+func (table *CacheTable) SetPersistence(path string, interval time.Duration) {
+	table.Lock()
+	if table.persistStop != nil {
+		close(table.persistStop)
+	}
+	stop := make(chan struct{})
+	table.persistStop = stop
+	table.Unlock()
+
+	go table.persistLoop(path, interval, stop)
+}
+
+func (table *CacheTable) persistLoop(path string, interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := table.flushToFile(path); err != nil {
+				table.log("Persistence flush failed for table", table.name, ":", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (table *CacheTable) flushToFile(path string) error {
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(table.snapshotItems()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
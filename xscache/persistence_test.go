@@ -0,0 +1,71 @@
+package xscache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	table := newCacheTable("persist-roundtrip", 1)
+	table.Add("alive", "alive-value", 1*time.Hour)
+	table.Add("gone", "gone-value", 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+
+	path := filepath.Join(t.TempDir(), "snapshot.gob")
+	if err := table.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	loaded := newCacheTable("persist-roundtrip-loaded", 1)
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	if !loaded.Exists("alive") {
+		t.Error("Exists(\"alive\") = false, want true")
+	}
+	item, err := loaded.Value("alive")
+	if err != nil {
+		t.Fatalf("Value(\"alive\") error = %v", err)
+	}
+	if item.Data() != "alive-value" {
+		t.Errorf("Value(\"alive\").Data() = %v, want %q", item.Data(), "alive-value")
+	}
+
+	if loaded.Exists("gone") {
+		t.Error("Exists(\"gone\") = true, want false: expired item should be dropped on load")
+	}
+}
+
+func TestLoadFromFileBackfillsEvictionPolicyAndStats(t *testing.T) {
+	source := newCacheTable("persist-backfill-source", 1)
+	source.Add("a", 1, 0)
+	source.Add("b", 2, 0)
+	source.Add("c", 3, 0)
+
+	path := filepath.Join(t.TempDir(), "snapshot.gob")
+	if err := source.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	loaded := newCacheTable("persist-backfill-loaded", 1)
+	loaded.SetCapacity(2, NewLRUPolicy())
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	if got := loaded.Stats().Adds; got != 3 {
+		t.Errorf("Stats().Adds = %d, want 3: restored items should be counted as adds", got)
+	}
+
+	loaded.Add("d", 4, 0) // over capacity, must evict one of the restored keys
+
+	if got := loaded.Count(); got != 2 {
+		t.Fatalf("Count() = %d, want 2: eviction policy should have tracked the restored keys", got)
+	}
+	if !loaded.Exists("d") {
+		t.Error("Exists(\"d\") = false, want true: newly added key should survive eviction")
+	}
+}
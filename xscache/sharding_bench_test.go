@@ -0,0 +1,46 @@
+package xscache
+
+import (
+	"strconv"
+	"testing"
+)
+
+// benchmarkConcurrentAccess 并发地对同一个表做读写, 用于衡量分片数量对吞吐的影响
+// 每个P使用独立的本地计数器生成key, 避免基准测试自身在goroutine间引入额外的竞争
+func benchmarkConcurrentAccess(b *testing.B, table *CacheTable) {
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var n int64
+		for pb.Next() {
+			n++
+			key := "key_" + strconv.FormatInt(n%1000, 10)
+			table.Add(key, n, 0)
+			table.Value(key)
+		}
+	})
+}
+
+func BenchmarkCacheTable_SingleMutex(b *testing.B) {
+	table := newCacheTable("bench-single", 1)
+	benchmarkConcurrentAccess(b, table)
+}
+
+func BenchmarkCacheTable_Sharded16(b *testing.B) {
+	table := newCacheTable("bench-sharded-16", 16)
+	benchmarkConcurrentAccess(b, table)
+}
+
+func BenchmarkCacheTable_Sharded64(b *testing.B) {
+	table := newCacheTable("bench-sharded-64", 64)
+	benchmarkConcurrentAccess(b, table)
+}
+
+// 要看出分片数量对吞吐的影响, 必须用多个GOMAXPROCS跑同一组基准并比较ns/op, 例如:
+//
+//	go test -run=^$ -bench=BenchmarkCacheTable -cpu=1,2,4,8 -benchtime=1s ./...
+//
+// 在当前实现下预期看到的结果是: Sharded16/Sharded64在cpu=1时与SingleMutex相近,
+// 但随着cpu数增加三者的ns/op会一起线性上升, 分片并不能换来明显更好的扩展性。
+// 原因是addInternal和Value在每次调用时仍然会对table.RWMutex做RLock来读取
+// 回调列表、loadData、evictionPolicy等配置, 这把所有分片重新串行化回了同一把表级锁上,
+// shard自身的锁只消除了items map读写的竞争, 没有消除配置读取的竞争。
@@ -0,0 +1,49 @@
+package xscache
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestShardedTableRoutesConsistently 验证分片表对同一个key总是落在同一个分片上,
+// 且跨分片的增删改查行为与单分片表完全一致
+func TestShardedTableRoutesConsistently(t *testing.T) {
+	table := newCacheTable("sharding-routing", 8)
+
+	for i := 0; i < 100; i++ {
+		key := "key_" + strconv.Itoa(i)
+		table.Add(key, i, 0)
+
+		first := table.shardFor(key)
+		second := table.shardFor(key)
+		if first != second {
+			t.Fatalf("shardFor(%q) is not stable across calls", key)
+		}
+	}
+
+	if got := table.Count(); got != 100 {
+		t.Fatalf("Count() = %d, want 100", got)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := "key_" + strconv.Itoa(i)
+		item, err := table.Value(key)
+		if err != nil {
+			t.Fatalf("Value(%q) error = %v", key, err)
+		}
+		if item.Data() != i {
+			t.Errorf("Value(%q).Data() = %v, want %d", key, item.Data(), i)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		key := "key_" + strconv.Itoa(i)
+		if _, err := table.Delete(key); err != nil {
+			t.Fatalf("Delete(%q) error = %v", key, err)
+		}
+	}
+
+	if got := table.Count(); got != 0 {
+		t.Fatalf("Count() = %d after deleting all keys, want 0", got)
+	}
+}
@@ -0,0 +1,106 @@
+package xscache
+
+import "sync/atomic"
+
+// DeleteReason 表示一个元素被移出表的具体原因, 用于区分统计口径
+type DeleteReason int
+
+const (
+	// DeleteReasonExplicit 调用方通过Delete显式删除
+	DeleteReasonExplicit DeleteReason = iota
+	// DeleteReasonExpired 元素因TTL到期被移除
+	DeleteReasonExpired
+	// DeleteReasonEvicted 元素因超出容量被淘汰策略移除
+	DeleteReasonEvicted
+	// DeleteReasonFlushed 元素因调用Flush被整体清空
+	DeleteReasonFlushed
+)
+
+// tableStats 是表级别的原子计数器, 字段全部通过atomic包访问
+type tableStats struct {
+	hits            int64
+	misses          int64
+	loadHits        int64
+	loadMisses      int64
+	adds            int64
+	deletesExplicit int64
+	deletesExpired  int64
+	deletesEvicted  int64
+	deletesFlushed  int64
+}
+
+func (s *tableStats) recordDelete(reason DeleteReason, n int64) {
+	switch reason {
+	case DeleteReasonExplicit:
+		atomic.AddInt64(&s.deletesExplicit, n)
+	case DeleteReasonExpired:
+		atomic.AddInt64(&s.deletesExpired, n)
+	case DeleteReasonEvicted:
+		atomic.AddInt64(&s.deletesEvicted, n)
+	case DeleteReasonFlushed:
+		atomic.AddInt64(&s.deletesFlushed, n)
+	}
+}
+
+// Stats是某一时刻CacheTable计数器的快照
+type Stats struct {
+	// Hits是命中Value()的次数
+	Hits int64
+	// Misses是Value()未在表中找到key的次数, 无论之后数据加载器是否加载成功
+	Misses int64
+	// LoadHits是数据加载器成功返回数据的次数
+	LoadHits int64
+	// LoadMisses是数据加载器返回nil的次数
+	LoadMisses int64
+	// Adds是成功加入表中的元素总数
+	Adds int64
+	// DeletesExplicit是通过Delete显式删除的元素总数
+	DeletesExplicit int64
+	// DeletesExpired是因TTL到期被移除的元素总数
+	DeletesExpired int64
+	// DeletesEvicted是因超出容量被淘汰策略移除的元素总数
+	DeletesEvicted int64
+	// DeletesFlushed是因调用Flush被清空的元素总数
+	DeletesFlushed int64
+	// Size是当前表中元素总数
+	Size int
+}
+
+// Stats 返回当前表的计数器快照
+func (table *CacheTable) Stats() Stats {
+	return Stats{
+		Hits:            atomic.LoadInt64(&table.stats.hits),
+		Misses:          atomic.LoadInt64(&table.stats.misses),
+		LoadHits:        atomic.LoadInt64(&table.stats.loadHits),
+		LoadMisses:      atomic.LoadInt64(&table.stats.loadMisses),
+		Adds:            atomic.LoadInt64(&table.stats.adds),
+		DeletesExplicit: atomic.LoadInt64(&table.stats.deletesExplicit),
+		DeletesExpired:  atomic.LoadInt64(&table.stats.deletesExpired),
+		DeletesEvicted:  atomic.LoadInt64(&table.stats.deletesEvicted),
+		DeletesFlushed:  atomic.LoadInt64(&table.stats.deletesFlushed),
+		Size:            table.Count(),
+	}
+}
+
+// ResetStats 将所有计数器清零, 不影响表中已有的元素
+func (table *CacheTable) ResetStats() {
+	atomic.StoreInt64(&table.stats.hits, 0)
+	atomic.StoreInt64(&table.stats.misses, 0)
+	atomic.StoreInt64(&table.stats.loadHits, 0)
+	atomic.StoreInt64(&table.stats.loadMisses, 0)
+	atomic.StoreInt64(&table.stats.adds, 0)
+	atomic.StoreInt64(&table.stats.deletesExplicit, 0)
+	atomic.StoreInt64(&table.stats.deletesExpired, 0)
+	atomic.StoreInt64(&table.stats.deletesEvicted, 0)
+	atomic.StoreInt64(&table.stats.deletesFlushed, 0)
+}
+
+// EvictionCount 返回当前表因超出容量被淘汰策略移除的元素总数
+func (table *CacheTable) EvictionCount() int64 {
+	return atomic.LoadInt64(&table.stats.deletesEvicted)
+}
+
+// ExpirationCount 返回当前表因TTL过期被移除的元素总数
+func (table *CacheTable) ExpirationCount() int64 {
+	return atomic.LoadInt64(&table.stats.deletesExpired)
+}
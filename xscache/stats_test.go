@@ -0,0 +1,76 @@
+package xscache
+
+import "testing"
+
+func TestStatsTracksHitsMissesAndDeletes(t *testing.T) {
+	table := newCacheTable("stats-basic", 1)
+
+	table.Add("key", "value", 0)
+	table.Value("key")     // hit
+	table.Value("missing") // miss
+	table.Delete("key")    // explicit delete
+
+	stats := table.Stats()
+	if stats.Adds != 1 {
+		t.Errorf("Adds = %d, want 1", stats.Adds)
+	}
+	if stats.Hits != 1 {
+		t.Errorf("Hits = %d, want 1", stats.Hits)
+	}
+	if stats.Misses != 1 {
+		t.Errorf("Misses = %d, want 1", stats.Misses)
+	}
+	if stats.DeletesExplicit != 1 {
+		t.Errorf("DeletesExplicit = %d, want 1", stats.DeletesExplicit)
+	}
+	if stats.Size != 0 {
+		t.Errorf("Size = %d, want 0", stats.Size)
+	}
+}
+
+func TestStatsTracksLoaderAndEviction(t *testing.T) {
+	table := newCacheTable("stats-loader", 1)
+	table.SetCapacity(1, NewLRUPolicy())
+	table.SetDataLoader(func(key interface{}, args ...interface{}) *CacheItem {
+		if key == "loadable" {
+			return NewCacheItem(key, "loaded", 0)
+		}
+		return nil
+	})
+
+	if _, err := table.Value("loadable"); err != nil {
+		t.Fatalf("Value(\"loadable\") error = %v", err)
+	}
+	if _, err := table.Value("missing"); err != ErrKeyNotFoundOrLoadable {
+		t.Errorf("Value(\"missing\") error = %v, want ErrKeyNotFoundOrLoadable", err)
+	}
+
+	table.Add("evictor", "data", 0)
+
+	stats := table.Stats()
+	if stats.LoadHits != 1 {
+		t.Errorf("LoadHits = %d, want 1", stats.LoadHits)
+	}
+	if stats.LoadMisses != 1 {
+		t.Errorf("LoadMisses = %d, want 1", stats.LoadMisses)
+	}
+	if stats.DeletesEvicted != 1 {
+		t.Errorf("DeletesEvicted = %d, want 1", stats.DeletesEvicted)
+	}
+}
+
+func TestResetStatsZeroesCountersButNotSize(t *testing.T) {
+	table := newCacheTable("stats-reset", 1)
+	table.Add("key", "value", 0)
+	table.Value("key")
+
+	table.ResetStats()
+
+	stats := table.Stats()
+	if stats.Hits != 0 || stats.Adds != 0 {
+		t.Errorf("Hits = %d, Adds = %d, want 0 and 0 after ResetStats", stats.Hits, stats.Adds)
+	}
+	if stats.Size != 1 {
+		t.Errorf("Size = %d, want 1: ResetStats must not remove items", stats.Size)
+	}
+}
@@ -18,14 +18,50 @@ func Cache(table string) *CacheTable {
 	// 检查表是否存在, 不存在则创建
 	if !ok {
 		mutex.Lock()
-		t = &CacheTable{
-			name:  table,
-			items: make(map[interface{}]*CacheItem),
+		t, ok = cache[table]
+		if !ok {
+			t = newCacheTable(table, 1)
+			cache[table] = t
 		}
+		mutex.Unlock()
+	}
+
+	return t
+}
 
-		cache[table] = t
+// CacheSharded 返回一个存在的表, 或创建一个shards个分片的新缓存表
+// 每个分片拥有独立的锁, 用于在高并发场景下减少单一互斥锁带来的竞争;
+// shards<=1时退化为与Cache(table)等价的单分片表
+func CacheSharded(table string, shards int) *CacheTable {
+	mutex.RLock()
+	t, ok := cache[table]
+	mutex.RUnlock()
+
+	if !ok {
+		mutex.Lock()
+		t, ok = cache[table]
+		if !ok {
+			t = newCacheTable(table, shards)
+			cache[table] = t
+		}
 		mutex.Unlock()
 	}
 
 	return t
 }
+
+func newCacheTable(name string, shards int) *CacheTable {
+	if shards < 1 {
+		shards = 1
+	}
+
+	t := &CacheTable{
+		name:   name,
+		shards: make([]*cacheShard, shards),
+	}
+	for i := range t.shards {
+		t.shards[i] = newCacheShard()
+	}
+
+	return t
+}